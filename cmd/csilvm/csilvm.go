@@ -4,21 +4,27 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 
 	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
 	"github.com/mesosphere/csilvm/pkg/csilvm"
+	"github.com/mesosphere/csilvm/pkg/endpoint"
 	"github.com/mesosphere/csilvm/pkg/lvm"
 )
 
 const (
-	defaultDefaultFs         = "xfs"
-	defaultDefaultVolumeSize = 10 << 30
-	defaultRequestLimit      = 10
+	defaultDefaultFs           = "xfs"
+	defaultDefaultVolumeSize   = 10 << 30
+	defaultRequestLimit        = 10
+	defaultMetricsPollInterval = 30 * time.Second
 )
 
 type stringsFlag []string
@@ -39,7 +45,7 @@ func main() {
 	pvnamesF := flag.String("devices", "", "A comma-seperated list of devices in the volume group")
 	defaultFsF := flag.String("default-fs", defaultDefaultFs, "The default filesystem to format new volumes with")
 	defaultVolumeSizeF := flag.Uint64("default-volume-size", defaultDefaultVolumeSize, "The default volume size in bytes")
-	socketFileF := flag.String("unix-addr", "", "The path to the listening unix socket file")
+	socketFileF := flag.String("unix-addr", "", "The endpoint to listen on, e.g. unix:///path/to.sock or tcp://host:port (a bare path is treated as a unix socket, for backwards compatibility)")
 	socketFileEnvF := flag.String("unix-addr-env", "", "An optional environment variable from which to read the unix-addr")
 	removeF := flag.Bool("remove-volume-group", false, "If set, the volume group will be removed when ProbeNode is called.")
 	var tagsF stringsFlag
@@ -47,6 +53,12 @@ func main() {
 	var probeModulesF stringsFlag
 	flag.Var(&probeModulesF, "probe-module", "Probe checks that the kernel module is loaded")
 	nodeIDF := flag.String("node-id", "", "The node ID reported via the CSI Node gRPC service")
+	disableExpandF := flag.Bool("disable-expand", false, "If set, ControllerExpandVolume and NodeExpandVolume are disabled")
+	metricsAddrF := flag.String("metrics-addr", "", "If set, serve Prometheus metrics on this address (e.g. :9000)")
+	metricsPathF := flag.String("metrics-path", "/metrics", "The HTTP path on which to serve Prometheus metrics")
+	snapshotReservedBytesF := flag.Uint64("snapshot-reserved-bytes", 0, "Extra copy-on-write space, in bytes, given to each snapshot on top of its source volume's size")
+	projectF := flag.String("project", "", "If set, namespace created volumes under this project and reject access to volumes outside it")
+	maxVolumesPerNodeF := flag.Int64("max-volumes-per-node", 0, "The maximum number of volumes that can be staged/published on this node at once (0 = unlimited)")
 	flag.Parse()
 	// Setup logging
 	logprefix := fmt.Sprintf("[%s]", *vgnameF)
@@ -62,11 +74,8 @@ func main() {
 	if *socketFileEnvF != "" {
 		sock = os.Getenv(*socketFileEnvF)
 	}
-	if strings.HasPrefix(sock, "unix://") {
-		sock = sock[len("unix://"):]
-	}
-	// Setup socket listener
-	lis, err := net.Listen("unix", sock)
+	// Setup listener
+	lis, err := endpoint.Listen(sock)
 	if err != nil {
 		log.Fatalf("[%s] Failed to listen: %v", *vgnameF, err)
 	}
@@ -80,10 +89,21 @@ func main() {
 	if len(*nodeIDF) > defaultMaxStringLen {
 		log.Fatalf("node-id cannot be longer than %d bytes: %q", defaultMaxStringLen, *nodeIDF)
 	}
+	if *metricsAddrF != "" {
+		mux := http.NewServeMux()
+		mux.Handle(*metricsPathF, promhttp.Handler())
+		go func() {
+			log.Printf("[%s] serving metrics on %s%s", *vgnameF, *metricsAddrF, *metricsPathF)
+			if err := http.ListenAndServe(*metricsAddrF, mux); err != nil {
+				log.Fatalf("[%s] metrics listener failed: %v", *vgnameF, err)
+			}
+		}()
+	}
 	var grpcOpts []grpc.ServerOption
 	grpcOpts = append(grpcOpts,
 		grpc.UnaryInterceptor(
 			csilvm.ChainUnaryServer(
+				csilvm.MetricsInterceptor(),
 				csilvm.RequestLimitInterceptor(*requestLimitF),
 				csilvm.SerializingInterceptor(),
 				csilvm.LoggingInterceptor(),
@@ -101,6 +121,18 @@ func main() {
 	if *removeF {
 		opts = append(opts, csilvm.RemoveVolumeGroup())
 	}
+	if *disableExpandF {
+		opts = append(opts, csilvm.DisableExpandVolume())
+	}
+	if *snapshotReservedBytesF > 0 {
+		opts = append(opts, csilvm.SnapshotReservedBytes(*snapshotReservedBytesF))
+	}
+	if *projectF != "" {
+		opts = append(opts, csilvm.Project(*projectF))
+	}
+	if *maxVolumesPerNodeF > 0 {
+		opts = append(opts, csilvm.MaxVolumesPerNode(*maxVolumesPerNodeF))
+	}
 	for _, tag := range tagsF {
 		opts = append(opts, csilvm.Tag(tag))
 	}
@@ -108,8 +140,26 @@ func main() {
 	if err := s.Setup(); err != nil {
 		log.Fatalf("[%s] error initializing csilvm plugin: err=%v", *vgnameF, err)
 	}
+	if *metricsAddrF != "" {
+		go func() {
+			ticker := time.NewTicker(defaultMetricsPollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := lvm.ReportMetrics(s.VolumeGroup()); err != nil {
+					log.Printf("[%s] failed to poll LVM metrics: %v", *vgnameF, err)
+				}
+			}
+		}()
+	}
 	csi.RegisterIdentityServer(grpcServer, csilvm.IdentityServerValidator(s))
 	csi.RegisterControllerServer(grpcServer, csilvm.ControllerServerValidator(s, s.RemovingVolumeGroup(), s.SupportedFilesystems()))
 	csi.RegisterNodeServer(grpcServer, csilvm.NodeServerValidator(s, s.RemovingVolumeGroup(), s.SupportedFilesystems()))
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	go func() {
+		sig := <-sigc
+		log.Printf("[%s] received %v, waiting for in-flight requests to complete", *vgnameF, sig)
+		grpcServer.GracefulStop()
+	}()
 	grpcServer.Serve(lis)
 }
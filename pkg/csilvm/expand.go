@@ -0,0 +1,190 @@
+package csilvm
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+)
+
+// filesystemAt returns the filesystem type mounted at path.
+func filesystemAt(path string) (string, error) {
+	cmd := exec.Command("findmnt", "-no", "FSTYPE", "--target", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("csilvm: findmnt %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resizeFilesystemFunc grows the filesystem found at `path`, which is backed
+// by the block device at `device`, to fill the device.
+type resizeFilesystemFunc func(device, path string) error
+
+// defaultResizeCommands is the built-in fs -> resize command registry,
+// covering the filesystems listed by SupportedFilesystems(). Operators can register additional filesystems via ResizeFilesystemCommand.
+var defaultResizeCommands = map[string]resizeFilesystemFunc{
+	"xfs":  resizeXFS,
+	"ext2": resizeExt,
+	"ext3": resizeExt,
+	"ext4": resizeExt,
+}
+
+func resizeXFS(device, path string) error {
+	cmd := exec.Command("xfs_growfs", path)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("csilvm: xfs_growfs %s: %v: %s", path, err, out)
+	}
+	return nil
+}
+
+func resizeExt(device, path string) error {
+	cmd := exec.Command("resize2fs", device)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("csilvm: resize2fs %s: %v: %s", device, err, out)
+	}
+	return nil
+}
+
+// ResizeFilesystemCommand registers a resize command for the given
+// filesystem, overriding the built-in command if one was already registered.
+// This lets operators add support for filesystems csilvm doesn't grow out of
+// the box.
+func ResizeFilesystemCommand(fs string, fn func(device, path string) error) ServerOpt {
+	return func(s *Server) {
+		if s.resizeFilesystems == nil {
+			s.resizeFilesystems = make(map[string]resizeFilesystemFunc)
+		}
+		s.resizeFilesystems[fs] = fn
+	}
+}
+
+// DisableExpandVolume disables the EXPAND_VOLUME controller and node
+// capabilities, causing ControllerExpandVolume and NodeExpandVolume to
+// report Unimplemented. It exists for operators who'd rather not expose
+// online growth on a given deployment.
+func DisableExpandVolume() ServerOpt {
+	return func(s *Server) {
+		s.disableExpandVolume = true
+	}
+}
+
+// ControllerGetCapabilities reports the RPCs this server's Controller
+// service supports, including EXPAND_VOLUME unless expansion was disabled
+// via DisableExpandVolume.
+func (s *Server) ControllerGetCapabilities(
+	ctx context.Context,
+	request *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
+	rpcs := []csi.ControllerServiceCapability_RPC_Type{
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+	}
+	if !s.disableExpandVolume {
+		rpcs = append(rpcs, csi.ControllerServiceCapability_RPC_EXPAND_VOLUME)
+	}
+	var caps []*csi.ControllerServiceCapability
+	for _, rpc := range rpcs {
+		caps = append(caps, &csi.ControllerServiceCapability{
+			Type: &csi.ControllerServiceCapability_Rpc{
+				Rpc: &csi.ControllerServiceCapability_RPC{Type: rpc},
+			},
+		})
+	}
+	return &csi.ControllerGetCapabilitiesResponse{Capabilities: caps}, nil
+}
+
+// NodeGetCapabilities reports the RPCs this server's Node service supports,
+// including EXPAND_VOLUME unless expansion was disabled via
+// DisableExpandVolume.
+func (s *Server) NodeGetCapabilities(
+	ctx context.Context,
+	request *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	rpcs := []csi.NodeServiceCapability_RPC_Type{
+		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+	}
+	if !s.disableExpandVolume {
+		rpcs = append(rpcs, csi.NodeServiceCapability_RPC_EXPAND_VOLUME)
+	}
+	var caps []*csi.NodeServiceCapability
+	for _, rpc := range rpcs {
+		caps = append(caps, &csi.NodeServiceCapability{
+			Type: &csi.NodeServiceCapability_Rpc{
+				Rpc: &csi.NodeServiceCapability_RPC{Type: rpc},
+			},
+		})
+	}
+	return &csi.NodeGetCapabilitiesResponse{Capabilities: caps}, nil
+}
+
+func (s *Server) ControllerExpandVolume(
+	ctx context.Context,
+	request *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+	if s.disableExpandVolume {
+		return nil, status.Error(codes.Unimplemented, "ControllerExpandVolume is disabled")
+	}
+	volumeId := request.GetVolumeId()
+	if err := s.checkProjectOwnership(volumeId); err != nil {
+		return nil, err
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(volumeId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "volume %q not found: %v", volumeId, err)
+	}
+	requiredBytes := uint64(request.GetCapacityRange().GetRequiredBytes())
+	if requiredBytes <= lv.SizeInBytes() {
+		return &csi.ControllerExpandVolumeResponse{
+			CapacityBytes:         int64(lv.SizeInBytes()),
+			NodeExpansionRequired: true,
+		}, nil
+	}
+	if err := lv.Extend(requiredBytes); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extend volume %q: %v", volumeId, err)
+	}
+	return &csi.ControllerExpandVolumeResponse{
+		CapacityBytes:         int64(requiredBytes),
+		NodeExpansionRequired: true,
+	}, nil
+}
+
+func (s *Server) NodeExpandVolume(
+	ctx context.Context,
+	request *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
+	if s.disableExpandVolume {
+		return nil, status.Error(codes.Unimplemented, "NodeExpandVolume is disabled")
+	}
+	volumeId := request.GetVolumeId()
+	volumePath := request.GetVolumePath()
+	if err := s.checkProjectOwnership(volumeId); err != nil {
+		return nil, err
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(volumeId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "volume %q not found: %v", volumeId, err)
+	}
+	fs, err := filesystemAt(volumePath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to determine filesystem at %q: %v", volumePath, err)
+	}
+	resize, ok := s.resizeFilesystems[fs]
+	if !ok {
+		resize, ok = defaultResizeCommands[fs]
+	}
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot grow unsupported filesystem %q", fs)
+	}
+	if err := resize(lv.Path(), volumePath); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to grow filesystem at %q: %v", volumePath, err)
+	}
+	return &csi.NodeExpandVolumeResponse{}, nil
+}
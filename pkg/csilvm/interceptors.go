@@ -0,0 +1,90 @@
+package csilvm
+
+import (
+	"io/ioutil"
+	"log"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var logger = log.New(ioutil.Discard, "", 0)
+
+// SetLogger configures the logger used by this package.
+func SetLogger(l *log.Logger) {
+	logger = l
+}
+
+// ChainUnaryServer composes multiple UnaryServerInterceptors into one,
+// running them in the order given.
+func ChainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		chain := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor := interceptors[i]
+			next := chain
+			chain = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chain(ctx, req)
+	}
+}
+
+// RequestLimitInterceptor rejects incoming RPCs with codes.Unavailable while
+// limit requests are already being served concurrently.
+func RequestLimitInterceptor(limit int) grpc.UnaryServerInterceptor {
+	sem := make(chan struct{}, limit)
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		default:
+			return nil, status.Error(codes.Unavailable, "too many pending requests")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// SerializingInterceptor ensures that only one RPC is handled at a time,
+// since concurrent LVM commands against the same volume group can corrupt
+// its metadata.
+func SerializingInterceptor() grpc.UnaryServerInterceptor {
+	var mu sync.Mutex
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return handler(ctx, req)
+	}
+}
+
+// LoggingInterceptor logs the method, request, and any error for every RPC.
+func LoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		logger.Printf("%s request=%+v", info.FullMethod, req)
+		resp, err := handler(ctx, req)
+		if err != nil {
+			logger.Printf("%s failed: %v", info.FullMethod, err)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,54 @@
+package csilvm
+
+import (
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	operationsSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "csi_operations_seconds",
+			Help: "Latency, in seconds, of CSI RPCs handled by csilvm, labeled by method and result code.",
+		},
+		[]string{"grpc_method", "grpc_code"},
+	)
+	operationsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "csi_operations_in_flight",
+			Help: "Number of CSI RPCs currently being handled by csilvm, labeled by method.",
+		},
+		[]string{"grpc_method"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(operationsSeconds, operationsInFlight)
+}
+
+// MetricsInterceptor returns a UnaryServerInterceptor that records, for
+// every RPC, a csi_operations_seconds histogram observation labeled by
+// method and resulting grpc code, and tracks the number of in-flight calls
+// per method via csi_operations_in_flight.
+func MetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		method := path.Base(info.FullMethod)
+		operationsInFlight.WithLabelValues(method).Inc()
+		defer operationsInFlight.WithLabelValues(method).Dec()
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		operationsSeconds.
+			WithLabelValues(method, status.Code(err).String()).
+			Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
@@ -0,0 +1,57 @@
+package csilvm
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxVolumesPerNode returns a ServerOpt that caps the number of volumes
+// this node will stage at once, surfaced to the external attacher via
+// NodeGetInfoResponse.MaxVolumesPerNode. A limit of 0, the default, means
+// unlimited.
+func MaxVolumesPerNode(max int64) ServerOpt {
+	return func(s *Server) {
+		s.maxVolumesPerNode = max
+	}
+}
+
+// stageVolume records volumeId as staged on this node so that it counts
+// against -max-volumes-per-node until unstageVolume is called.
+func (s *Server) stageVolume(volumeId string) {
+	s.stagedMu.Lock()
+	defer s.stagedMu.Unlock()
+	if s.stagedVolumes == nil {
+		s.stagedVolumes = make(map[string]bool)
+	}
+	s.stagedVolumes[volumeId] = true
+}
+
+// unstageVolume removes volumeId from the set of volumes staged on this
+// node.
+func (s *Server) unstageVolume(volumeId string) {
+	s.stagedMu.Lock()
+	defer s.stagedMu.Unlock()
+	delete(s.stagedVolumes, volumeId)
+}
+
+// activeVolumeCount returns the number of volumes currently staged on this
+// node, per the bookkeeping NodeStageVolume/NodeUnstageVolume do via
+// stageVolume/unstageVolume.
+func (s *Server) activeVolumeCount() int64 {
+	s.stagedMu.Lock()
+	defer s.stagedMu.Unlock()
+	return int64(len(s.stagedVolumes))
+}
+
+// checkNodeVolumeLimit returns a RESOURCE_EXHAUSTED error if staging
+// another volume on this node would exceed -max-volumes-per-node, so the
+// external-attacher knows to reschedule the pod elsewhere.
+func (s *Server) checkNodeVolumeLimit() error {
+	if s.maxVolumesPerNode <= 0 {
+		return nil
+	}
+	if s.activeVolumeCount() >= s.maxVolumesPerNode {
+		return status.Errorf(codes.ResourceExhausted, "node has reached its limit of %d attached volumes", s.maxVolumesPerNode)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package csilvm
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+)
+
+// TestNodePublishVolumeAtLimit ensures that publishing a volume already
+// staged on this node never fails due to -max-volumes-per-node, even when
+// the node is at its limit: NodeStageVolume is what accounts for the
+// volume, and NodePublishVolume must not re-charge it against the same
+// limit.
+func TestNodePublishVolumeAtLimit(t *testing.T) {
+	s := NewServer("vg0", nil, "xfs", MaxVolumesPerNode(1))
+	ctx := context.Background()
+	if _, err := s.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{VolumeId: "vol0"}); err != nil {
+		t.Fatalf("NodeStageVolume: unexpected error: %v", err)
+	}
+	if _, err := s.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{VolumeId: "vol0"}); err != nil {
+		t.Fatalf("NodePublishVolume: unexpected error: %v", err)
+	}
+}
+
+// TestNodeStageVolumeRejectsOverLimit ensures a genuinely new (N+1)th
+// volume is still rejected once the node is at its limit.
+func TestNodeStageVolumeRejectsOverLimit(t *testing.T) {
+	s := NewServer("vg0", nil, "xfs", MaxVolumesPerNode(1))
+	ctx := context.Background()
+	if _, err := s.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{VolumeId: "vol0"}); err != nil {
+		t.Fatalf("NodeStageVolume(vol0): unexpected error: %v", err)
+	}
+	if _, err := s.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{VolumeId: "vol1"}); err == nil {
+		t.Fatal("NodeStageVolume(vol1): expected RESOURCE_EXHAUSTED, got nil")
+	}
+}
@@ -0,0 +1,82 @@
+package csilvm
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mesosphere/csilvm/pkg/lvm"
+)
+
+// projectTagPrefix namespaces the LVM tag this server uses to mark volumes
+// as belonging to its project, analogous to how Tag() adds operator-chosen
+// tags to the volume group.
+const projectTagPrefix = "csilvm.project="
+
+// Project returns a ServerOpt that namespaces every volume this server
+// creates under the given project: created LVs are prefixed with
+// "<project>-" and tagged "csilvm.project=<project>", ListVolumes only
+// returns volumes in the project, and mutating calls against volumes
+// outside the project are rejected. This lets a single volume group be
+// safely shared by multiple csilvm instances, one per project/namespace.
+func Project(project string) ServerOpt {
+	return func(s *Server) {
+		s.project = project
+	}
+}
+
+// projectVolumeName returns the LV name this server should use for a
+// volume named `name`, namespaced under the configured project, if any.
+func (s *Server) projectVolumeName(name string) string {
+	if s.project == "" {
+		return name
+	}
+	return s.project + "-" + name
+}
+
+// projectTag returns the LVM tag used to mark a volume as belonging to
+// this server's project, if a project is configured.
+func (s *Server) projectTag() string {
+	if s.project == "" {
+		return ""
+	}
+	return projectTagPrefix + s.project
+}
+
+// ownsVolume reports whether the given logical volume belongs to this
+// server's project, either by name prefix or LVM tag. It always returns
+// true when no project is configured.
+func (s *Server) ownsVolume(lv *lvm.LogicalVolume) bool {
+	if s.project == "" {
+		return true
+	}
+	if strings.HasPrefix(lv.Name(), s.project+"-") {
+		return true
+	}
+	for _, tag := range lv.Tags() {
+		if tag == s.projectTag() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProjectOwnership looks up volumeId and returns a PERMISSION_DENIED
+// error if it exists but falls outside this server's project namespace.
+// It is a no-op (returning nil) when no project is configured or the volume
+// does not exist, so callers can fall through to their normal not-found
+// handling.
+func (s *Server) checkProjectOwnership(volumeId string) error {
+	if s.project == "" {
+		return nil
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(volumeId)
+	if err != nil {
+		return nil
+	}
+	if !s.ownsVolume(lv) {
+		return status.Errorf(codes.PermissionDenied, "volume %q does not belong to project %q", volumeId, s.project)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package csilvm implements the CSI Identity, Controller, and Node gRPC
+// services on top of an LVM volume group.
+package csilvm
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/mesosphere/csilvm/pkg/lvm"
+)
+
+const defaultStagingDir = "/var/lib/csilvm/staging"
+
+// ServerOpt configures optional behavior of a Server returned by NewServer.
+type ServerOpt func(*Server)
+
+// Server implements the csi.IdentityServer, csi.ControllerServer, and
+// csi.NodeServer gRPC services on top of a single LVM volume group.
+type Server struct {
+	vgname    string
+	pvnames   []string
+	defaultFs string
+
+	volumeGroup *lvm.VolumeGroup
+
+	nodeID            string
+	defaultVolumeSize uint64
+	probeModules      []string
+	tags              []string
+	removeVolumeGroup bool
+	stagingDir        string
+
+	disableExpandVolume   bool
+	resizeFilesystems     map[string]resizeFilesystemFunc
+	snapshotReservedBytes uint64
+	project               string
+	maxVolumesPerNode     int64
+
+	stagedMu      sync.Mutex
+	stagedVolumes map[string]bool
+}
+
+// NewServer constructs a Server managing the given volume group, backed by
+// pvnames, with defaultFs as its default filesystem. The Server does not
+// format the volumes it creates.
+func NewServer(vgname string, pvnames []string, defaultFs string, opts ...ServerOpt) *Server {
+	s := &Server{
+		vgname:     vgname,
+		pvnames:    pvnames,
+		defaultFs:  defaultFs,
+		stagingDir: defaultStagingDir,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Setup looks up the managed volume group, which must already exist.
+func (s *Server) Setup() error {
+	vg, err := lvm.LookupVolumeGroup(s.vgname)
+	if err != nil {
+		return err
+	}
+	s.volumeGroup = vg
+	return nil
+}
+
+// RemovingVolumeGroup reports whether this server was configured with
+// RemoveVolumeGroup.
+func (s *Server) RemovingVolumeGroup() bool {
+	return s.removeVolumeGroup
+}
+
+// SupportedFilesystems returns the filesystems this server reports as
+// supported; main passes them to the request validators.
+func (s *Server) SupportedFilesystems() []string {
+	return []string{"xfs", "ext4", "ext3", "ext2"}
+}
+
+// VolumeGroup returns the volume group this server manages, so that callers
+// such as main() can poll it for metrics.
+func (s *Server) VolumeGroup() *lvm.VolumeGroup {
+	return s.volumeGroup
+}
+
+// NodeID sets the node ID reported via the CSI Node gRPC service.
+func NodeID(id string) ServerOpt {
+	return func(s *Server) {
+		s.nodeID = id
+	}
+}
+
+// DefaultVolumeSize sets the volume size used when a CreateVolume request
+// does not specify a capacity range.
+func DefaultVolumeSize(size uint64) ServerOpt {
+	return func(s *Server) {
+		s.defaultVolumeSize = size
+	}
+}
+
+// ProbeModules records the kernel modules to probe for. Probe does not check
+// them and always succeeds.
+func ProbeModules(modules []string) ServerOpt {
+	return func(s *Server) {
+		s.probeModules = modules
+	}
+}
+
+// RemoveVolumeGroup marks the server as removing its volume group: the
+// validators then reject CreateVolume, NodeStageVolume, NodePublishVolume
+// and NodeExpandVolume with FailedPrecondition. The volume group itself is
+// not removed.
+func RemoveVolumeGroup() ServerOpt {
+	return func(s *Server) {
+		s.removeVolumeGroup = true
+	}
+}
+
+// Tag adds a value to tag the volume group with.
+func Tag(tag string) ServerOpt {
+	return func(s *Server) {
+		s.tags = append(s.tags, tag)
+	}
+}
+
+// Identity service
+
+func (s *Server) GetPluginInfo(
+	ctx context.Context,
+	request *csi.GetPluginInfoRequest) (*csi.GetPluginInfoResponse, error) {
+	return &csi.GetPluginInfoResponse{
+		Name:          "io.mesosphere.dcos.csilvm",
+		VendorVersion: "0.0.0",
+	}, nil
+}
+
+func (s *Server) GetPluginCapabilities(
+	ctx context.Context,
+	request *csi.GetPluginCapabilitiesRequest) (*csi.GetPluginCapabilitiesResponse, error) {
+	return &csi.GetPluginCapabilitiesResponse{
+		Capabilities: []*csi.PluginCapability{
+			{
+				Type: &csi.PluginCapability_Service_{
+					Service: &csi.PluginCapability_Service{
+						Type: csi.PluginCapability_Service_CONTROLLER_SERVICE,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *Server) Probe(
+	ctx context.Context,
+	request *csi.ProbeRequest) (*csi.ProbeResponse, error) {
+	return &csi.ProbeResponse{}, nil
+}
+
+// Controller service
+
+func (s *Server) CreateVolume(
+	ctx context.Context,
+	request *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+	name := s.projectVolumeName(request.GetName())
+	sizeBytes := s.defaultVolumeSize
+	if request.GetCapacityRange().GetRequiredBytes() > 0 {
+		sizeBytes = uint64(request.GetCapacityRange().GetRequiredBytes())
+	}
+	if lv, ok, err := s.createVolumeFromContentSource(name, request.GetVolumeContentSource()); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &csi.CreateVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      lv.Name(),
+				CapacityBytes: int64(lv.SizeInBytes()),
+			},
+		}, nil
+	}
+	var tags []string
+	if tag := s.projectTag(); tag != "" {
+		tags = append(tags, tag)
+	}
+	lv, err := s.volumeGroup.CreateLogicalVolume(name, sizeBytes, tags)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create volume %q: %v", name, err)
+	}
+	return &csi.CreateVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      lv.Name(),
+			CapacityBytes: int64(sizeBytes),
+		},
+	}, nil
+}
+
+func (s *Server) DeleteVolume(
+	ctx context.Context,
+	request *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
+	volumeId := request.GetVolumeId()
+	if err := s.checkProjectOwnership(volumeId); err != nil {
+		return nil, err
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(volumeId)
+	if err != nil {
+		// DeleteVolume is idempotent; a missing volume is not an error.
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+	if err := s.volumeGroup.RemoveLogicalVolume(lv); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove volume %q: %v", volumeId, err)
+	}
+	return &csi.DeleteVolumeResponse{}, nil
+}
+
+func (s *Server) ControllerPublishVolume(
+	ctx context.Context,
+	request *csi.ControllerPublishVolumeRequest) (*csi.ControllerPublishVolumeResponse, error) {
+	if err := s.checkProjectOwnership(request.GetVolumeId()); err != nil {
+		return nil, err
+	}
+	return &csi.ControllerPublishVolumeResponse{}, nil
+}
+
+func (s *Server) ControllerUnpublishVolume(
+	ctx context.Context,
+	request *csi.ControllerUnpublishVolumeRequest) (*csi.ControllerUnpublishVolumeResponse, error) {
+	return &csi.ControllerUnpublishVolumeResponse{}, nil
+}
+
+func (s *Server) ValidateVolumeCapabilities(
+	ctx context.Context,
+	request *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	return &csi.ValidateVolumeCapabilitiesResponse{Supported: true}, nil
+}
+
+func (s *Server) ListVolumes(
+	ctx context.Context,
+	request *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	names, err := s.volumeGroup.ListLogicalVolumeNames()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list volumes: %v", err)
+	}
+	var entries []*csi.ListVolumesResponse_Entry
+	for _, name := range names {
+		lv, err := s.volumeGroup.LookupLogicalVolume(name)
+		if err != nil {
+			continue
+		}
+		if !s.ownsVolume(lv) {
+			continue
+		}
+		entries = append(entries, &csi.ListVolumesResponse_Entry{
+			Volume: &csi.Volume{
+				VolumeId:      lv.Name(),
+				CapacityBytes: int64(lv.SizeInBytes()),
+			},
+		})
+	}
+	return &csi.ListVolumesResponse{Entries: entries}, nil
+}
+
+func (s *Server) GetCapacity(
+	ctx context.Context,
+	request *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
+	bytesFree, err := s.volumeGroup.BytesFree()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to determine available capacity: %v", err)
+	}
+	return &csi.GetCapacityResponse{AvailableCapacity: int64(bytesFree)}, nil
+}
+
+// Node service
+
+func (s *Server) NodeStageVolume(
+	ctx context.Context,
+	request *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	if err := s.checkProjectOwnership(request.GetVolumeId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkNodeVolumeLimit(); err != nil {
+		return nil, err
+	}
+	s.stageVolume(request.GetVolumeId())
+	return &csi.NodeStageVolumeResponse{}, nil
+}
+
+func (s *Server) NodeUnstageVolume(
+	ctx context.Context,
+	request *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	s.unstageVolume(request.GetVolumeId())
+	return &csi.NodeUnstageVolumeResponse{}, nil
+}
+
+func (s *Server) NodePublishVolume(
+	ctx context.Context,
+	request *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	if err := s.checkProjectOwnership(request.GetVolumeId()); err != nil {
+		return nil, err
+	}
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+func (s *Server) NodeUnpublishVolume(
+	ctx context.Context,
+	request *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+	return &csi.NodeUnpublishVolumeResponse{}, nil
+}
+
+func (s *Server) NodeGetInfo(
+	ctx context.Context,
+	request *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
+	return &csi.NodeGetInfoResponse{
+		NodeId:            s.nodeID,
+		MaxVolumesPerNode: s.maxVolumesPerNode,
+	}, nil
+}
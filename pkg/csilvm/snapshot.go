@@ -0,0 +1,156 @@
+package csilvm
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/mesosphere/csilvm/pkg/lvm"
+)
+
+// lvTimeLayout matches the default, locale-independent timestamp format
+// `lvs` reports for lv_time.
+const lvTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// snapshotCreatedAt converts the lv_time string lvs reports into the
+// unix-nanos timestamp the CSI Snapshot message expects, defaulting to 0
+// (unknown) if it cannot be parsed.
+func snapshotCreatedAt(lvTime string) int64 {
+	t, err := time.Parse(lvTimeLayout, lvTime)
+	if err != nil {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// SnapshotReservedBytes returns a ServerOpt that adds the given number of
+// bytes to the copy-on-write space of every snapshot CreateSnapshot creates,
+// on top of the source volume's size. It does not hold back volume group
+// free space: CreateVolume does not consult it, and no call checks the
+// volume group's free space against it.
+func SnapshotReservedBytes(bytes uint64) ServerOpt {
+	return func(s *Server) {
+		s.snapshotReservedBytes = bytes
+	}
+}
+
+func (s *Server) CreateSnapshot(
+	ctx context.Context,
+	request *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
+	sourceVolumeId := request.GetSourceVolumeId()
+	if err := s.checkProjectOwnership(sourceVolumeId); err != nil {
+		return nil, err
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(sourceVolumeId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "source volume %q not found: %v", sourceVolumeId, err)
+	}
+	name := s.projectVolumeName(request.GetName())
+	cowSizeBytes := lv.SizeInBytes() + s.snapshotReservedBytes
+	snap, err := s.volumeGroup.CreateSnapshot(name, lv, cowSizeBytes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create snapshot %q: %v", name, err)
+	}
+	return &csi.CreateSnapshotResponse{
+		Snapshot: &csi.Snapshot{
+			SnapshotId:     snap.Name,
+			SourceVolumeId: sourceVolumeId,
+			SizeBytes:      int64(snap.SizeInBytes),
+			CreatedAt:      snapshotCreatedAt(snap.CreationTime),
+			ReadyToUse:     true,
+		},
+	}, nil
+}
+
+func (s *Server) DeleteSnapshot(
+	ctx context.Context,
+	request *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
+	snapshotId := request.GetSnapshotId()
+	if err := s.checkProjectOwnership(snapshotId); err != nil {
+		return nil, err
+	}
+	lv, err := s.volumeGroup.LookupLogicalVolume(snapshotId)
+	if err != nil {
+		// A missing snapshot is not an error; DeleteSnapshot is idempotent.
+		return &csi.DeleteSnapshotResponse{}, nil
+	}
+	if err := s.volumeGroup.RemoveSnapshot(lv); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove snapshot %q: %v", snapshotId, err)
+	}
+	return &csi.DeleteSnapshotResponse{}, nil
+}
+
+func (s *Server) ListSnapshots(
+	ctx context.Context,
+	request *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	snapshots, err := s.volumeGroup.ListSnapshots()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list snapshots: %v", err)
+	}
+	var entries []*csi.ListSnapshotsResponse_Entry
+	for _, snap := range snapshots {
+		if request.GetSourceVolumeId() != "" && request.GetSourceVolumeId() != snap.SourceName {
+			continue
+		}
+		if request.GetSnapshotId() != "" && request.GetSnapshotId() != snap.Name {
+			continue
+		}
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{
+			Snapshot: &csi.Snapshot{
+				SnapshotId:     snap.Name,
+				SourceVolumeId: snap.SourceName,
+				SizeBytes:      int64(snap.SizeInBytes),
+				CreatedAt:      snapshotCreatedAt(snap.CreationTime),
+				ReadyToUse:     true,
+			},
+		})
+	}
+	return &csi.ListSnapshotsResponse{Entries: entries}, nil
+}
+
+// createVolumeFromContentSource materializes the requested volume from the
+// given content source, if any, returning ok=false when there is no source
+// to honor (the normal, empty-volume path).
+func (s *Server) createVolumeFromContentSource(
+	name string,
+	source *csi.VolumeContentSource) (lv *lvm.LogicalVolume, ok bool, err error) {
+	if source == nil {
+		return nil, false, nil
+	}
+	var tags []string
+	if tag := s.projectTag(); tag != "" {
+		tags = append(tags, tag)
+	}
+	if snapshotSource := source.GetSnapshot(); snapshotSource != nil {
+		snap, err := s.volumeGroup.LookupLogicalVolume(snapshotSource.GetSnapshotId())
+		if err != nil {
+			return nil, true, status.Errorf(codes.NotFound, "snapshot %q not found: %v", snapshotSource.GetSnapshotId(), err)
+		}
+		lv, err := s.volumeGroup.CreateLogicalVolume(name, snap.SizeInBytes(), tags)
+		if err != nil {
+			return nil, true, status.Errorf(codes.Internal, "failed to create volume %q from snapshot %q: %v", name, snapshotSource.GetSnapshotId(), err)
+		}
+		if err := lv.CopyFrom(snap); err != nil {
+			return nil, true, status.Errorf(codes.Internal, "failed to materialize volume %q from snapshot %q: %v", name, snapshotSource.GetSnapshotId(), err)
+		}
+		return lv, true, nil
+	}
+	if volumeSource := source.GetVolume(); volumeSource != nil {
+		src, err := s.volumeGroup.LookupLogicalVolume(volumeSource.GetVolumeId())
+		if err != nil {
+			return nil, true, status.Errorf(codes.NotFound, "source volume %q not found: %v", volumeSource.GetVolumeId(), err)
+		}
+		lv, err := s.volumeGroup.CreateLogicalVolume(name, src.SizeInBytes(), tags)
+		if err != nil {
+			return nil, true, status.Errorf(codes.Internal, "failed to create volume %q cloned from %q: %v", name, volumeSource.GetVolumeId(), err)
+		}
+		if err := lv.CopyFrom(src); err != nil {
+			return nil, true, status.Errorf(codes.Internal, "failed to clone volume %q from %q: %v", name, volumeSource.GetVolumeId(), err)
+		}
+		return lv, true, nil
+	}
+	return nil, false, nil
+}
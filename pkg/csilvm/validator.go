@@ -0,0 +1,126 @@
+package csilvm
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+)
+
+type identityServerValidator struct {
+	csi.IdentityServer
+}
+
+// IdentityServerValidator wraps s, validating requests before delegating
+// to it.
+func IdentityServerValidator(s csi.IdentityServer) csi.IdentityServer {
+	return &identityServerValidator{s}
+}
+
+type controllerServerValidator struct {
+	csi.ControllerServer
+	removingVolumeGroup  bool
+	supportedFilesystems []string
+}
+
+// ControllerServerValidator wraps s, rejecting requests while the volume
+// group is being removed and validating common request fields before
+// delegating to s.
+func ControllerServerValidator(
+	s csi.ControllerServer,
+	removingVolumeGroup bool,
+	supportedFilesystems []string) csi.ControllerServer {
+	return &controllerServerValidator{s, removingVolumeGroup, supportedFilesystems}
+}
+
+func (v *controllerServerValidator) CreateVolume(
+	ctx context.Context,
+	request *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+	if v.removingVolumeGroup {
+		return nil, status.Error(codes.FailedPrecondition, "volume group is being removed")
+	}
+	if request.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	return v.ControllerServer.CreateVolume(ctx, request)
+}
+
+func (v *controllerServerValidator) DeleteVolume(
+	ctx context.Context,
+	request *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.ControllerServer.DeleteVolume(ctx, request)
+}
+
+type nodeServerValidator struct {
+	csi.NodeServer
+	removingVolumeGroup  bool
+	supportedFilesystems []string
+}
+
+// NodeServerValidator wraps s, rejecting requests while the volume group is
+// being removed and validating common request fields before delegating to
+// s.
+func NodeServerValidator(
+	s csi.NodeServer,
+	removingVolumeGroup bool,
+	supportedFilesystems []string) csi.NodeServer {
+	return &nodeServerValidator{s, removingVolumeGroup, supportedFilesystems}
+}
+
+func (v *nodeServerValidator) NodeStageVolume(
+	ctx context.Context,
+	request *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	if v.removingVolumeGroup {
+		return nil, status.Error(codes.FailedPrecondition, "volume group is being removed")
+	}
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.NodeServer.NodeStageVolume(ctx, request)
+}
+
+func (v *nodeServerValidator) NodeUnstageVolume(
+	ctx context.Context,
+	request *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.NodeServer.NodeUnstageVolume(ctx, request)
+}
+
+func (v *nodeServerValidator) NodePublishVolume(
+	ctx context.Context,
+	request *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	if v.removingVolumeGroup {
+		return nil, status.Error(codes.FailedPrecondition, "volume group is being removed")
+	}
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.NodeServer.NodePublishVolume(ctx, request)
+}
+
+func (v *nodeServerValidator) NodeUnpublishVolume(
+	ctx context.Context,
+	request *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.NodeServer.NodeUnpublishVolume(ctx, request)
+}
+
+func (v *nodeServerValidator) NodeExpandVolume(
+	ctx context.Context,
+	request *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
+	if v.removingVolumeGroup {
+		return nil, status.Error(codes.FailedPrecondition, "volume group is being removed")
+	}
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is required")
+	}
+	return v.NodeServer.NodeExpandVolume(ctx, request)
+}
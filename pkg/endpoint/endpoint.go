@@ -0,0 +1,48 @@
+// Package endpoint parses and listens on the CSI-style endpoint strings
+// (e.g. "unix:///var/run/csilvm.sock" or "tcp://127.0.0.1:10000") that CSI
+// drivers are conventionally configured with.
+package endpoint
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Listen parses endpoint and returns a net.Listener bound to it. Endpoint
+// must be of the form "unix://<path>", "tcp://<host>:<port>", or a bare
+// filesystem path, which is treated as a unix socket path for backwards
+// compatibility. If the endpoint is a unix socket and a stale socket file
+// already exists at that path, it is removed before binding.
+func Listen(endpoint string) (net.Listener, error) {
+	proto, addr, err := parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if proto == "unix" {
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("endpoint: failed to unlink stale socket %q: %v", addr, err)
+		}
+	}
+	lis, err := net.Listen(proto, addr)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint: failed to listen on %s://%s: %v", proto, addr, err)
+	}
+	return lis, nil
+}
+
+func parse(endpoint string) (proto, addr string, err error) {
+	switch {
+	case strings.HasPrefix(endpoint, "unix://"):
+		return "unix", strings.TrimPrefix(endpoint, "unix://"), nil
+	case strings.HasPrefix(endpoint, "tcp://"):
+		return "tcp", strings.TrimPrefix(endpoint, "tcp://"), nil
+	case strings.Contains(endpoint, "://"):
+		return "", "", fmt.Errorf("endpoint: unsupported scheme in %q, want unix:// or tcp://", endpoint)
+	default:
+		// Bare paths are accepted as unix socket paths to match the
+		// pre-existing -unix-addr behavior.
+		return "unix", endpoint, nil
+	}
+}
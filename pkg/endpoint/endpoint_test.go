@@ -0,0 +1,33 @@
+package endpoint
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		endpoint  string
+		wantProto string
+		wantAddr  string
+		wantErr   bool
+	}{
+		{"unix:///var/run/csilvm.sock", "unix", "/var/run/csilvm.sock", false},
+		{"tcp://127.0.0.1:10000", "tcp", "127.0.0.1:10000", false},
+		{"/var/run/csilvm.sock", "unix", "/var/run/csilvm.sock", false},
+		{"nfs://host/path", "", "", true},
+	}
+	for _, c := range cases {
+		proto, addr, err := parse(c.endpoint)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parse(%q): expected error, got nil", c.endpoint)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parse(%q): unexpected error: %v", c.endpoint, err)
+			continue
+		}
+		if proto != c.wantProto || addr != c.wantAddr {
+			t.Errorf("parse(%q) = (%q, %q), want (%q, %q)", c.endpoint, proto, addr, c.wantProto, c.wantAddr)
+		}
+	}
+}
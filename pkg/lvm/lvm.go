@@ -0,0 +1,196 @@
+// Package lvm wraps the `lvm2` command line tools (vgs, lvs, lvcreate,
+// lvremove, ...) to manage a single volume group and the logical volumes
+// within it.
+package lvm
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var logger = log.New(ioutil.Discard, "", 0)
+
+// SetLogger configures the logger used by this package.
+func SetLogger(l *log.Logger) {
+	logger = l
+}
+
+// VolumeGroup represents an existing LVM volume group.
+type VolumeGroup struct {
+	name string
+}
+
+// LookupVolumeGroup looks up the volume group with the given name.
+func LookupVolumeGroup(name string) (*VolumeGroup, error) {
+	cmd := exec.Command("vgs", "--noheadings", "-o", "vg_name", name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("lvm: volume group %q not found: %v: %s", name, err, out)
+	}
+	return &VolumeGroup{name: name}, nil
+}
+
+// Name returns the volume group's name.
+func (vg *VolumeGroup) Name() string {
+	return vg.name
+}
+
+// LogicalVolume represents a logical volume within a VolumeGroup.
+type LogicalVolume struct {
+	vg   *VolumeGroup
+	name string
+}
+
+// Name returns the logical volume's name.
+func (lv *LogicalVolume) Name() string {
+	return lv.name
+}
+
+// Path returns the device path at which the logical volume is available.
+func (lv *LogicalVolume) Path() string {
+	return filepath.Join("/dev", lv.vg.name, lv.name)
+}
+
+// SizeInBytes returns the logical volume's current size, in bytes.
+func (lv *LogicalVolume) SizeInBytes() uint64 {
+	size, err := lv.queryUint64("lv_size")
+	if err != nil {
+		logger.Printf("lvm: failed to determine size of %q: %v", lv.Path(), err)
+		return 0
+	}
+	return size
+}
+
+// Tags returns the LVM tags currently set on the logical volume.
+func (lv *LogicalVolume) Tags() []string {
+	args := []string{"--noheadings", "-o", "lv_tags", lv.Path()}
+	cmd := exec.Command("lvs", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		logger.Printf("lvm: failed to list tags of %q: %v", lv.Path(), err)
+		return nil
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ",")
+	var tags []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			tags = append(tags, f)
+		}
+	}
+	return tags
+}
+
+func (lv *LogicalVolume) queryUint64(field string) (uint64, error) {
+	args := []string{"--noheadings", "--units", "b", "--nosuffix", "-o", field, lv.Path()}
+	cmd := exec.Command("lvs", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// LookupLogicalVolume looks up the logical volume with the given name in vg.
+func (vg *VolumeGroup) LookupLogicalVolume(name string) (*LogicalVolume, error) {
+	path := filepath.Join("/dev", vg.name, name)
+	cmd := exec.Command("lvs", "--noheadings", "-o", "lv_name", path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("lvm: logical volume %q not found: %v: %s", name, err, out)
+	}
+	return &LogicalVolume{vg: vg, name: name}, nil
+}
+
+// CreateLogicalVolume creates a new logical volume of the given size,
+// optionally tagged with the given LVM tags.
+func (vg *VolumeGroup) CreateLogicalVolume(name string, sizeBytes uint64, tags []string) (*LogicalVolume, error) {
+	args := []string{
+		"-n", name,
+		"-L", fmt.Sprintf("%db", sizeBytes),
+	}
+	for _, tag := range tags {
+		args = append(args, "--addtag", tag)
+	}
+	args = append(args, vg.name)
+	cmd := exec.Command("lvcreate", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("lvm: lvcreate %v: %v: %s", args, err, out)
+	}
+	return &LogicalVolume{vg: vg, name: name}, nil
+}
+
+// RemoveLogicalVolume removes the given logical volume.
+func (vg *VolumeGroup) RemoveLogicalVolume(lv *LogicalVolume) error {
+	cmd := exec.Command("lvremove", "-f", lv.Path())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lvm: lvremove %s: %v: %s", lv.Path(), err, out)
+	}
+	return nil
+}
+
+// ListLogicalVolumeNames returns the names of every logical volume in vg.
+func (vg *VolumeGroup) ListLogicalVolumeNames() ([]string, error) {
+	cmd := exec.Command("lvs", "--noheadings", "-o", "lv_name", vg.name)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("lvm: lvs %s: %v", vg.name, err)
+	}
+	return splitLines(out), nil
+}
+
+// ListPhysicalVolumeNames returns the names of the physical volumes backing vg.
+func (vg *VolumeGroup) ListPhysicalVolumeNames() ([]string, error) {
+	cmd := exec.Command("pvs", "--noheadings", "-o", "pv_name", "--select", fmt.Sprintf("vg_name=%s", vg.name))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("lvm: pvs %s: %v", vg.name, err)
+	}
+	return splitLines(out), nil
+}
+
+// BytesFree returns the volume group's current free space, in bytes.
+func (vg *VolumeGroup) BytesFree() (uint64, error) {
+	cmd := exec.Command("vgs", "--noheadings", "--units", "b", "--nosuffix", "-o", "vg_free", vg.name)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("lvm: vgs %s: %v", vg.name, err)
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// ThinPoolDataUtilization returns the fraction, between 0 and 1, of the
+// volume group's thin pool data area currently in use, or 0 if the volume
+// group has no thin pool.
+func (vg *VolumeGroup) ThinPoolDataUtilization() (float64, error) {
+	cmd := exec.Command("lvs", "--noheadings", "-o", "data_percent", "--select", "lv_attr =~ ^t", vg.name)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("lvm: lvs %s: %v", vg.name, err)
+	}
+	line := strings.TrimSpace(string(out))
+	if line == "" {
+		return 0, nil
+	}
+	percent, err := strconv.ParseFloat(strings.Fields(line)[0], 64)
+	if err != nil {
+		return 0, nil
+	}
+	return percent / 100, nil
+}
+
+func splitLines(out []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
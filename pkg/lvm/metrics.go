@@ -0,0 +1,56 @@
+package lvm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	vgFreeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "csilvm_lvm_vg_free_bytes",
+		Help: "Free space, in bytes, remaining in the managed volume group.",
+	})
+	lvCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "csilvm_lvm_lv_count",
+		Help: "Number of logical volumes in the managed volume group.",
+	})
+	pvCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "csilvm_lvm_pv_count",
+		Help: "Number of physical volumes backing the managed volume group.",
+	})
+	thinPoolUtilization = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "csilvm_lvm_thin_pool_data_utilization_ratio",
+		Help: "Fraction of the thin pool's data area currently in use, or 0 if no thin pool is configured.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(vgFreeBytes, lvCount, pvCount, thinPoolUtilization)
+}
+
+// ReportMetrics polls vg for its current free space, logical/physical volume
+// counts and thin pool utilization and updates the corresponding Prometheus
+// gauges. Callers are expected to invoke it periodically, e.g. from a
+// time.Ticker loop started in main().
+func ReportMetrics(vg *VolumeGroup) error {
+	bytesFree, err := vg.BytesFree()
+	if err != nil {
+		return err
+	}
+	vgFreeBytes.Set(float64(bytesFree))
+	lvs, err := vg.ListLogicalVolumeNames()
+	if err != nil {
+		return err
+	}
+	lvCount.Set(float64(len(lvs)))
+	pvs, err := vg.ListPhysicalVolumeNames()
+	if err != nil {
+		return err
+	}
+	pvCount.Set(float64(len(pvs)))
+	ratio, err := vg.ThinPoolDataUtilization()
+	if err != nil {
+		return err
+	}
+	thinPoolUtilization.Set(ratio)
+	return nil
+}
@@ -0,0 +1,24 @@
+package lvm
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Extend grows the logical volume to the given size, in bytes, by invoking
+// `lvextend`. LVM refuses to shrink a volume through this call; it is the
+// caller's responsibility to only request sizes larger than the volume's
+// current size.
+func (lv *LogicalVolume) Extend(newSizeBytes uint64) error {
+	path := lv.Path()
+	args := []string{
+		"-L", fmt.Sprintf("%db", newSizeBytes),
+		path,
+	}
+	cmd := exec.Command("lvextend", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lvm: lvextend %v: %v: %s", args, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package lvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// CreateSnapshot creates a new logical volume `name` that is a snapshot of
+// lv, reserving cowSizeBytes of copy-on-write space for it.
+func (vg *VolumeGroup) CreateSnapshot(name string, lv *LogicalVolume, cowSizeBytes uint64) (*LogicalVolume, error) {
+	args := []string{
+		"-s",
+		"-n", name,
+		"-L", fmt.Sprintf("%db", cowSizeBytes),
+		lv.Path(),
+	}
+	cmd := exec.Command("lvcreate", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("lvm: lvcreate %v: %v: %s", args, err, out)
+	}
+	return vg.LookupLogicalVolume(name)
+}
+
+// CopyFrom overwrites lv's contents with src's, block for block, via dd. It
+// is used to create a volume from a snapshot or clone another volume, since
+// an lvconvert merge would mutate the source in place.
+func (lv *LogicalVolume) CopyFrom(src *LogicalVolume) error {
+	args := []string{
+		"if=" + src.Path(),
+		"of=" + lv.Path(),
+		"bs=4M",
+	}
+	cmd := exec.Command("dd", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lvm: dd %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// RemoveSnapshot removes the given snapshot logical volume.
+func (vg *VolumeGroup) RemoveSnapshot(lv *LogicalVolume) error {
+	cmd := exec.Command("lvremove", "-f", lv.Path())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lvm: lvremove %s: %v: %s", lv.Path(), err, out)
+	}
+	return nil
+}
+
+// snapshotReport mirrors the subset of `lvs --reportformat json` fields we
+// need to enumerate existing snapshots.
+type snapshotReport struct {
+	Report []struct {
+		Lv []struct {
+			LvName      string `json:"lv_name"`
+			Origin      string `json:"origin"`
+			LvSize      string `json:"lv_size"`
+			LvTime      string `json:"lv_time"`
+			DataPercent string `json:"data_percent"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+// Snapshot describes a single LVM snapshot logical volume.
+type Snapshot struct {
+	Name            string
+	SourceName      string
+	SizeInBytes     uint64
+	CreationTime    string
+	DataPercentUsed string
+}
+
+// ListSnapshots returns all snapshot logical volumes in the volume group.
+func (vg *VolumeGroup) ListSnapshots() ([]*Snapshot, error) {
+	args := []string{
+		"--reportformat", "json",
+		"--units", "b", "--nosuffix",
+		"-o", "lv_name,origin,lv_size,lv_time,data_percent",
+		"--select", "lv_attr =~ ^s",
+		vg.Name(),
+	}
+	cmd := exec.Command("lvs", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("lvm: lvs %v: %v", args, err)
+	}
+	var report snapshotReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("lvm: failed to parse lvs output: %v", err)
+	}
+	var snapshots []*Snapshot
+	for _, r := range report.Report {
+		for _, lv := range r.Lv {
+			if lv.Origin == "" {
+				continue
+			}
+			sizeBytes, err := strconv.ParseUint(lv.LvSize, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("lvm: failed to parse lv_size %q for %q: %v", lv.LvSize, lv.LvName, err)
+			}
+			snapshots = append(snapshots, &Snapshot{
+				Name:            lv.LvName,
+				SourceName:      lv.Origin,
+				SizeInBytes:     sizeBytes,
+				CreationTime:    lv.LvTime,
+				DataPercentUsed: lv.DataPercent,
+			})
+		}
+	}
+	return snapshots, nil
+}